@@ -0,0 +1,146 @@
+// Package bitio provides bit-level reading and writing on top of an
+// underlying byte stream, with seek support for the reader side so that
+// callers can skip unread box payload without materializing it.
+package bitio
+
+import "io"
+
+// Reader is a bit-level reader. Bits are read most-significant-bit first,
+// matching the bitstream layout used throughout ISOBMFF.
+type Reader interface {
+	// ReadBits reads n bits (0 <= n <= 64) and returns them right-aligned.
+	ReadBits(n uint64) (uint64, error)
+
+	// ReadByte reads a single byte, regardless of the current bit alignment.
+	ReadByte() (byte, error)
+
+	// Align discards any bits remaining in the current byte and returns how
+	// many bits were skipped.
+	Align() uint64
+}
+
+// Writer is a bit-level writer. Bits are written most-significant-bit first.
+type Writer interface {
+	// WriteBits writes the low n bits (0 <= n <= 64) of v.
+	WriteBits(v uint64, n uint64) error
+
+	// WriteByte writes a single byte, regardless of the current bit alignment.
+	WriteByte(b byte) error
+
+	// Align pads the current byte with zero bits and returns how many bits
+	// were written as padding.
+	Align() uint64
+}
+
+// ReadSeeker is a Reader that can also seek the underlying stream. Seeking
+// always realigns to a byte boundary, discarding any buffered bits.
+type ReadSeeker interface {
+	Reader
+	Seek(offset int64, whence int) (int64, error)
+}
+
+type reader struct {
+	rs   io.ReadSeeker
+	buf  byte
+	nbit uint
+}
+
+// NewReadSeeker returns a ReadSeeker that reads bits from rs.
+func NewReadSeeker(rs io.ReadSeeker) ReadSeeker {
+	return &reader{rs: rs}
+}
+
+func (r *reader) readBit() (uint64, error) {
+	if r.nbit == 0 {
+		var b [1]byte
+		if _, err := io.ReadFull(r.rs, b[:]); err != nil {
+			return 0, err
+		}
+		r.buf = b[0]
+		r.nbit = 8
+	}
+	r.nbit--
+	return uint64((r.buf >> r.nbit) & 1), nil
+}
+
+func (r *reader) ReadBits(n uint64) (uint64, error) {
+	var v uint64
+	for i := uint64(0); i < n; i++ {
+		bit, err := r.readBit()
+		if err != nil {
+			return 0, err
+		}
+		v = (v << 1) | bit
+	}
+	return v, nil
+}
+
+func (r *reader) ReadByte() (byte, error) {
+	if r.nbit == 0 {
+		var b [1]byte
+		if _, err := io.ReadFull(r.rs, b[:]); err != nil {
+			return 0, err
+		}
+		return b[0], nil
+	}
+	v, err := r.ReadBits(8)
+	return byte(v), err
+}
+
+func (r *reader) Align() uint64 {
+	skipped := uint64(r.nbit)
+	r.nbit = 0
+	return skipped
+}
+
+func (r *reader) Seek(offset int64, whence int) (int64, error) {
+	r.nbit = 0
+	return r.rs.Seek(offset, whence)
+}
+
+type writer struct {
+	w    io.Writer
+	buf  byte
+	nbit uint
+}
+
+// NewWriter returns a Writer that writes bits to w.
+func NewWriter(w io.Writer) Writer {
+	return &writer{w: w}
+}
+
+func (w *writer) writeBit(bit uint64) error {
+	w.buf = (w.buf << 1) | byte(bit&1)
+	w.nbit++
+	if w.nbit == 8 {
+		_, err := w.w.Write([]byte{w.buf})
+		w.buf = 0
+		w.nbit = 0
+		return err
+	}
+	return nil
+}
+
+func (w *writer) WriteBits(v uint64, n uint64) error {
+	for i := int64(n) - 1; i >= 0; i-- {
+		if err := w.writeBit((v >> uint(i)) & 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *writer) WriteByte(b byte) error {
+	return w.WriteBits(uint64(b), 8)
+}
+
+func (w *writer) Align() uint64 {
+	var filled uint64
+	for w.nbit != 0 {
+		if err := w.writeBit(0); err != nil {
+			break
+		}
+		filled++
+	}
+	return filled
+}
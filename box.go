@@ -7,6 +7,10 @@ import (
 	"github.com/abema/go-mp4/bitio"
 )
 
+// boxHeaderSize is the size in bytes of a standard 32-bit box header
+// (size + type), used to derive payload size from the total box size.
+const boxHeaderSize = 8
+
 type ICustomFieldObject interface {
 	// GetFieldSize returns size of dynamic field
 	GetFieldSize(string) uint
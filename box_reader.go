@@ -0,0 +1,155 @@
+package mp4
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// PayloadReader is an io.ReadSeeker scoped to a single box's payload. Reads
+// and seeks are relative to the payload's own start, regardless of where it
+// sits in the underlying stream. A size of -1 means the payload is
+// unbounded (it runs to EOF); this only occurs for a top-level BoxReader's
+// root and for a box with size 0 at the end of an unbounded parent.
+type PayloadReader struct {
+	r    io.ReadSeeker
+	base int64
+	size int64
+
+	consumed int64 // bytes read/sought to, relative to base
+	next     int64 // absolute offset to resume reading child headers from
+}
+
+func newPayloadReader(r io.ReadSeeker, base, size int64) *PayloadReader {
+	return &PayloadReader{r: r, base: base, size: size, next: base}
+}
+
+// Size returns the payload size in bytes, or -1 if it is unbounded.
+func (p *PayloadReader) Size() int64 {
+	return p.size
+}
+
+// Read implements io.Reader, reading payload bytes directly (e.g. mdat
+// sample data) without interpreting them as child boxes.
+func (p *PayloadReader) Read(buf []byte) (int, error) {
+	if p.size >= 0 {
+		if remaining := p.size - p.consumed; remaining <= 0 {
+			return 0, io.EOF
+		} else if int64(len(buf)) > remaining {
+			buf = buf[:remaining]
+		}
+	}
+	n, err := p.r.Read(buf)
+	p.consumed += int64(n)
+	return n, err
+}
+
+// Seek implements io.Seeker, relative to the payload's own start.
+func (p *PayloadReader) Seek(offset int64, whence int) (int64, error) {
+	var target int64
+	switch whence {
+	case io.SeekStart:
+		target = offset
+	case io.SeekCurrent:
+		target = p.consumed + offset
+	case io.SeekEnd:
+		if p.size < 0 {
+			return 0, fmt.Errorf("mp4: cannot seek relative to the end of an unbounded payload")
+		}
+		target = p.size + offset
+	default:
+		return 0, fmt.Errorf("mp4: invalid whence %d", whence)
+	}
+	abs, err := p.r.Seek(p.base+target, io.SeekStart)
+	if err != nil {
+		return 0, err
+	}
+	p.consumed = abs - p.base
+	return p.consumed, nil
+}
+
+// Next reads the next child box header, seeking past any of the previous
+// child's unread payload first, and returns its type and a PayloadReader
+// scoped to its payload. It returns io.EOF once the payload is exhausted.
+func (p *PayloadReader) Next() (BoxType, *PayloadReader, error) {
+	if p.size >= 0 && p.next-p.base >= p.size {
+		return BoxType{}, nil, io.EOF
+	}
+
+	if _, err := p.r.Seek(p.next, io.SeekStart); err != nil {
+		return BoxType{}, nil, err
+	}
+
+	var hdr [8]byte
+	if _, err := io.ReadFull(p.r, hdr[:]); err != nil {
+		return BoxType{}, nil, err
+	}
+	size := int64(binary.BigEndian.Uint32(hdr[:4]))
+	typ := BoxType{hdr[4], hdr[5], hdr[6], hdr[7]}
+	headerSize := int64(8)
+
+	switch size {
+	case 0:
+		if p.size < 0 {
+			size = -1
+		} else {
+			size = p.size - (p.next - p.base)
+		}
+	case 1:
+		var large [8]byte
+		if _, err := io.ReadFull(p.r, large[:]); err != nil {
+			return BoxType{}, nil, err
+		}
+		size = int64(binary.BigEndian.Uint64(large[:]))
+		headerSize = 16
+	}
+
+	payloadBase := p.next + headerSize
+	payloadSize := int64(-1)
+	if size >= 0 {
+		payloadSize = size - headerSize
+	}
+
+	child := newPayloadReader(p.r, payloadBase, payloadSize)
+	if payloadSize < 0 {
+		p.next = payloadBase
+	} else {
+		p.next = payloadBase + payloadSize
+	}
+	return typ, child, nil
+}
+
+// BoxReader streams the top-level boxes of r one at a time, without
+// requiring the whole file (or a single box's payload, e.g. a multi-gigabyte
+// mdat) to be read into memory first. It is the read-side counterpart to
+// BoxWriter, for paths like rewriting moof+mdat pairs.
+type BoxReader struct {
+	root *PayloadReader
+}
+
+// NewBoxReader returns a BoxReader that reads top-level boxes from r,
+// starting at r's current position.
+func NewBoxReader(r io.ReadSeeker) (*BoxReader, error) {
+	base, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	return &BoxReader{root: newPayloadReader(r, base, -1)}, nil
+}
+
+// Next returns the next top-level box's type and a PayloadReader scoped to
+// its payload, or io.EOF once r is exhausted.
+func (br *BoxReader) Next() (BoxType, *PayloadReader, error) {
+	return br.root.Next()
+}
+
+// UnmarshalFrom unmarshals box's reflect-based fields from p, bridging a
+// streaming PayloadReader (e.g. obtained while walking past a streamed mdat)
+// with the regular reflect-based Unmarshal.
+func UnmarshalFrom(p *PayloadReader, box IImmutableBox) (uint64, error) {
+	size := p.Size()
+	if size < 0 {
+		return 0, fmt.Errorf("mp4: UnmarshalFrom requires a bounded payload")
+	}
+	return Unmarshal(p, uint64(size)+boxHeaderSize, box)
+}
@@ -0,0 +1,30 @@
+package mp4
+
+// boxDef holds registration data for a box type, keyed by BoxType.
+type boxDef struct {
+	versions map[uint8]struct{}
+}
+
+var boxDefs = make(map[BoxType]*boxDef)
+
+// AddBoxDef registers box as the definition for its BoxType. versions lists
+// the FullBox versions the box supports; if empty, any version is accepted.
+func AddBoxDef(box IImmutableBox, versions ...uint8) {
+	def := &boxDef{versions: make(map[uint8]struct{}, len(versions))}
+	for _, v := range versions {
+		def.versions[v] = struct{}{}
+	}
+	boxDefs[box.GetType()] = def
+}
+
+// checkSupportedVersion reports whether version is acceptable for boxType,
+// per the versions passed to AddBoxDef. Unregistered box types accept any
+// version.
+func checkSupportedVersion(boxType BoxType, version uint8) bool {
+	def, ok := boxDefs[boxType]
+	if !ok || len(def.versions) == 0 {
+		return true
+	}
+	_, ok = def.versions[version]
+	return ok
+}
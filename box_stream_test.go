@@ -0,0 +1,278 @@
+package mp4
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memFile is a minimal in-memory io.WriteSeeker/io.ReadSeeker, standing in
+// for an *os.File in these tests.
+type memFile struct {
+	buf []byte
+	pos int64
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	end := f.pos + int64(len(p))
+	if end > int64(len(f.buf)) {
+		// append grows f.buf's backing array with spare capacity (doubling,
+		// roughly), instead of reallocating and copying the whole buffer on
+		// every call, so many small Write calls stay linear in the total size.
+		f.buf = append(f.buf, make([]byte, end-int64(len(f.buf)))...)
+	}
+	n := copy(f.buf[f.pos:end], p)
+	f.pos = end
+	return n, nil
+}
+
+func (f *memFile) Read(p []byte) (int, error) {
+	if f.pos >= int64(len(f.buf)) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.buf[f.pos:])
+	f.pos += int64(n)
+	return n, nil
+}
+
+func (f *memFile) Seek(offset int64, whence int) (int64, error) {
+	var target int64
+	switch whence {
+	case io.SeekStart:
+		target = offset
+	case io.SeekCurrent:
+		target = f.pos + offset
+	case io.SeekEnd:
+		target = int64(len(f.buf)) + offset
+	default:
+		return 0, fmt.Errorf("invalid whence %d", whence)
+	}
+	if target < 0 {
+		return 0, fmt.Errorf("negative seek position")
+	}
+	f.pos = target
+	return f.pos, nil
+}
+
+func TestBoxWriterReaderRoundTrip(t *testing.T) {
+	f := &memFile{}
+
+	freeBw, err := NewBoxWriter(f, StrToBoxType("free"))
+	require.NoError(t, err)
+	_, err = freeBw.Write([]byte{0x01, 0x02, 0x03})
+	require.NoError(t, err)
+	freeSize, err := freeBw.Close()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(11), freeSize)
+
+	mdatBw, err := NewBoxWriter(f, StrToBoxType("mdat"))
+	require.NoError(t, err)
+	_, err = mdatBw.Write([]byte("sample data"))
+	require.NoError(t, err)
+	_, err = mdatBw.Close()
+	require.NoError(t, err)
+
+	br, err := NewBoxReader(bytes.NewReader(f.buf))
+	require.NoError(t, err)
+
+	typ, p, err := br.Next()
+	require.NoError(t, err)
+	assert.Equal(t, StrToBoxType("free"), typ)
+	assert.Equal(t, int64(3), p.Size())
+	payload, err := ioutil.ReadAll(p)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x01, 0x02, 0x03}, payload)
+
+	typ, p, err = br.Next()
+	require.NoError(t, err)
+	assert.Equal(t, StrToBoxType("mdat"), typ)
+	payload, err = ioutil.ReadAll(p)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("sample data"), payload)
+
+	_, _, err = br.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestBoxWriterReaderNested(t *testing.T) {
+	f := &memFile{}
+
+	parentBw, err := NewBoxWriter(f, StrToBoxType("moov"))
+	require.NoError(t, err)
+	childBw, err := NewBoxWriter(f, StrToBoxType("trak"))
+	require.NoError(t, err)
+	_, err = childBw.Write([]byte("child payload"))
+	require.NoError(t, err)
+	_, err = childBw.Close()
+	require.NoError(t, err)
+	_, err = parentBw.Close()
+	require.NoError(t, err)
+
+	br, err := NewBoxReader(bytes.NewReader(f.buf))
+	require.NoError(t, err)
+
+	typ, parent, err := br.Next()
+	require.NoError(t, err)
+	assert.Equal(t, StrToBoxType("moov"), typ)
+
+	typ, child, err := parent.Next()
+	require.NoError(t, err)
+	assert.Equal(t, StrToBoxType("trak"), typ)
+	payload, err := ioutil.ReadAll(child)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("child payload"), payload)
+
+	_, _, err = parent.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestNewLargeBoxWriter(t *testing.T) {
+	f := &memFile{}
+
+	bw, err := NewLargeBoxWriter(f, StrToBoxType("mdat"))
+	require.NoError(t, err)
+	_, err = bw.Write([]byte("payload"))
+	require.NoError(t, err)
+	size, err := bw.Close()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(16+7), size)
+
+	// 32-bit size field carries the size=1 marker, the real size is in the
+	// 64-bit largesize field that follows the type.
+	assert.Equal(t, []byte{0, 0, 0, 1}, f.buf[0:4])
+	assert.Equal(t, size, uint64(f.buf[8])<<56|uint64(f.buf[9])<<48|uint64(f.buf[10])<<40|uint64(f.buf[11])<<32|
+		uint64(f.buf[12])<<24|uint64(f.buf[13])<<16|uint64(f.buf[14])<<8|uint64(f.buf[15]))
+
+	br, err := NewBoxReader(bytes.NewReader(f.buf))
+	require.NoError(t, err)
+	typ, p, err := br.Next()
+	require.NoError(t, err)
+	assert.Equal(t, StrToBoxType("mdat"), typ)
+	payload, err := ioutil.ReadAll(p)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("payload"), payload)
+}
+
+func TestBoxWriterCloseRejectsSizeOver32Bits(t *testing.T) {
+	f := &memFile{}
+	bw, err := NewBoxWriter(f, StrToBoxType("mdat"))
+	require.NoError(t, err)
+	// Simulate a payload that grew past the 32-bit size limit by seeking
+	// forward, without actually writing 4 GiB of data.
+	_, err = f.Seek(int64(math.MaxUint32)+1, io.SeekCurrent)
+	require.NoError(t, err)
+	_, err = bw.Close()
+	assert.Error(t, err)
+}
+
+func TestBoxWriterClosedTwice(t *testing.T) {
+	f := &memFile{}
+	bw, err := NewBoxWriter(f, StrToBoxType("free"))
+	require.NoError(t, err)
+	_, err = bw.Close()
+	require.NoError(t, err)
+	_, err = bw.Close()
+	assert.Error(t, err)
+}
+
+func TestMarshalToUnmarshalFrom(t *testing.T) {
+	boxType := StrToBoxType("tst4")
+	mb := mockBox{Type: boxType}
+	AddBoxDef(&testStreamBox{mockBox: mb}, 0)
+
+	src := &testStreamBox{
+		mockBox: mb,
+		Value:   0x11223344,
+	}
+
+	f := &memFile{}
+	bw, err := NewBoxWriter(f, boxType)
+	require.NoError(t, err)
+	_, err = MarshalTo(bw, src)
+	require.NoError(t, err)
+	_, err = bw.Close()
+	require.NoError(t, err)
+
+	br, err := NewBoxReader(bytes.NewReader(f.buf))
+	require.NoError(t, err)
+	typ, p, err := br.Next()
+	require.NoError(t, err)
+	assert.Equal(t, boxType, typ)
+
+	dst := &testStreamBox{mockBox: mb}
+	_, err = UnmarshalFrom(p, dst)
+	require.NoError(t, err)
+	assert.Equal(t, src, dst)
+}
+
+type testStreamBox struct {
+	mockBox
+	FullBox `mp4:"extend"`
+	Value   uint32 `mp4:"size=32"`
+}
+
+// BenchmarkBoxWriterLargePayload streams a 1 GiB payload through BoxWriter
+// via io.Copy to show that writing (and, symmetrically, reading it back via
+// PayloadReader) doesn't allocate memory proportional to the payload size --
+// the whole point of streaming a multi-gigabyte mdat instead of buffering
+// it. It's backed by a real *os.File rather than memFile: memFile retains
+// every byte written in a growing []byte, so it would make the benchmark's
+// own fixture allocate proportionally to payloadSize and defeat the point
+// of the measurement.
+func BenchmarkBoxWriterLargePayload(b *testing.B) {
+	const payloadSize = 1024 * 1024 * 1024
+	b.ReportAllocs()
+	b.SetBytes(payloadSize)
+	path := filepath.Join(b.TempDir(), "mdat.bin")
+	for i := 0; i < b.N; i++ {
+		f, err := os.Create(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		bw, err := NewLargeBoxWriter(f, StrToBoxType("mdat"))
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := io.Copy(bw, io.LimitReader(zeroReader{}, payloadSize)); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := bw.Close(); err != nil {
+			b.Fatal(err)
+		}
+
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			b.Fatal(err)
+		}
+		br, err := NewBoxReader(f)
+		if err != nil {
+			b.Fatal(err)
+		}
+		_, p, err := br.Next()
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := io.Copy(ioutil.Discard, p); err != nil {
+			b.Fatal(err)
+		}
+		if err := f.Close(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
@@ -0,0 +1,21 @@
+package mp4
+
+import "fmt"
+
+// BoxType is the four-character-code type of an ISOBMFF box.
+type BoxType [4]byte
+
+// String returns the four-character-code representation of the box type.
+func (boxType BoxType) String() string {
+	return string(boxType[:])
+}
+
+// StrToBoxType casts a four-character string to a BoxType. It panics if code
+// is not exactly four characters long, since box types are always defined as
+// compile-time constants.
+func StrToBoxType(code string) BoxType {
+	if len(code) != 4 {
+		panic(fmt.Errorf("invalid box type code: %s", code))
+	}
+	return BoxType{code[0], code[1], code[2], code[3]}
+}
@@ -0,0 +1,117 @@
+package mp4
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// BoxWriter streams a single box's payload directly to w without
+// materializing it, writing a placeholder header up front and patching in
+// the real size once the payload is known. This is the write-side
+// counterpart to BoxReader, for boxes too large to hold in memory (a
+// multi-gigabyte mdat) or packagers that want to rewrite moof+mdat pairs
+// without buffering samples. Nesting BoxWriters on the same w (starting a
+// child immediately after a parent's header) produces nested boxes.
+type BoxWriter struct {
+	w      io.WriteSeeker
+	typ    BoxType
+	base   int64
+	large  bool
+	closed bool
+}
+
+// NewBoxWriter writes a placeholder 8-byte header (size + type) for typ at
+// w's current position and returns a BoxWriter whose Write method streams
+// the box's payload. The size field is patched on Close.
+func NewBoxWriter(w io.WriteSeeker, typ BoxType) (*BoxWriter, error) {
+	return newBoxWriter(w, typ, false)
+}
+
+// NewLargeBoxWriter is like NewBoxWriter, but reserves a 16-byte header
+// (32-bit size=1 marker + 64-bit largesize) up front. Use it for boxes whose
+// payload may reach or exceed 4 GiB, such as a streamed mdat -- the header
+// width has to be fixed before any payload bytes are written, so it can't be
+// decided retroactively in Close.
+func NewLargeBoxWriter(w io.WriteSeeker, typ BoxType) (*BoxWriter, error) {
+	return newBoxWriter(w, typ, true)
+}
+
+func newBoxWriter(w io.WriteSeeker, typ BoxType, large bool) (*BoxWriter, error) {
+	base, err := w.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	hdr := make([]byte, 8)
+	copy(hdr[4:], typ[:])
+	if large {
+		hdr = append(hdr, make([]byte, 8)...)
+		hdr[3] = 1
+	}
+	if _, err := w.Write(hdr); err != nil {
+		return nil, err
+	}
+	return &BoxWriter{w: w, typ: typ, base: base, large: large}, nil
+}
+
+// Write streams payload bytes directly to the underlying writer.
+func (bw *BoxWriter) Write(p []byte) (int, error) {
+	return bw.w.Write(p)
+}
+
+// Close patches the box's size field now that the payload length is known,
+// and returns the box's total size (header included). The underlying writer
+// is left positioned at the end of the box, ready for a sibling. The size is
+// derived from the writer's current position rather than from bytes passed
+// to Write, so that nested child boxes written directly to w (the normal way
+// to nest BoxWriters) are accounted for.
+func (bw *BoxWriter) Close() (uint64, error) {
+	if bw.closed {
+		return 0, fmt.Errorf("mp4: BoxWriter for %s already closed", bw.typ)
+	}
+	bw.closed = true
+
+	headerSize := int64(8)
+	if bw.large {
+		headerSize = 16
+	}
+
+	end, err := bw.w.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	total := uint64(end - bw.base)
+
+	if !bw.large && total > math.MaxUint32 {
+		return 0, fmt.Errorf("mp4: box %s grew to %d bytes, too large for a 32-bit size; use NewLargeBoxWriter", bw.typ, total)
+	}
+
+	if _, err := bw.w.Seek(bw.base, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	hdr := make([]byte, headerSize)
+	copy(hdr[4:8], bw.typ[:])
+	if bw.large {
+		hdr[3] = 1
+		binary.BigEndian.PutUint64(hdr[8:16], total)
+	} else {
+		binary.BigEndian.PutUint32(hdr[0:4], uint32(total))
+	}
+	if _, err := bw.w.Write(hdr); err != nil {
+		return 0, err
+	}
+
+	if _, err := bw.w.Seek(end, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// MarshalTo marshals box's reflect-based payload into bw, bridging the
+// streaming writer with the regular reflect-based Marshal for boxes (e.g. a
+// moof alongside a streamed mdat) that don't need to be streamed themselves.
+func MarshalTo(bw *BoxWriter, box IImmutableBox) (uint64, error) {
+	return Marshal(bw, box)
+}
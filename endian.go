@@ -0,0 +1,26 @@
+package mp4
+
+// byteOrder is a field's resolved `endian=` tag. The zero value,
+// endianInherit, means the field carries no explicit override and should
+// use whatever byte order is ambient at that point in the struct (big-endian
+// at the top level, unless an enclosing `extend` field overrode it).
+type byteOrder int
+
+const (
+	endianInherit byteOrder = iota
+	endianBig
+	endianLittle
+)
+
+// resolve returns whether o should be encoded little-endian, given ambientLE
+// (the byte order in effect from an enclosing extend field, if any).
+func (o byteOrder) resolve(ambientLE bool) bool {
+	switch o {
+	case endianLittle:
+		return true
+	case endianBig:
+		return false
+	default:
+		return ambientLE
+	}
+}
@@ -0,0 +1,92 @@
+package mp4
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// uuidExifBox models a `uuid` box carrying a big-endian UUID header
+// followed by a little-endian ('II' byte order) TIFF-style payload, the
+// kind of mixed-endianness box this field tag is meant for.
+type uuidExifBox struct {
+	mockBox
+	FullBox `mp4:"extend"`
+
+	UserType [16]byte `mp4:"size=8,len=16"`
+
+	ByteOrderMark uint16   `mp4:"size=16"`
+	LEHeader      uint32   `mp4:"size=32,endian=le"`
+	LEEntries     []uint16 `mp4:"size=16,len=3,endian=le"`
+}
+
+func TestEndianLittleRoundTrip(t *testing.T) {
+	boxType := StrToBoxType("uuid")
+	mb := mockBox{Type: boxType}
+	AddBoxDef(&uuidExifBox{mockBox: mb}, 0)
+
+	src := uuidExifBox{
+		mockBox:       mb,
+		UserType:      [16]byte{0x01, 0x02, 0x03, 0x04},
+		ByteOrderMark: 0x4949, // 'II'
+		LEHeader:      0x00002a00,
+		LEEntries:     []uint16{1, 2, 3},
+	}
+
+	buf := &bytes.Buffer{}
+	n, err := Marshal(buf, &src)
+	require.NoError(t, err)
+
+	// LEHeader's bytes are reversed on the wire relative to big-endian.
+	bin := buf.Bytes()
+	leHeaderOffset := 1 + 3 + 16 + 2 // version+flags+UserType+ByteOrderMark
+	assert.Equal(t, []byte{0x00, 0x2a, 0x00, 0x00}, bin[leHeaderOffset:leHeaderOffset+4])
+
+	dst := uuidExifBox{mockBox: mb}
+	n2, err := Unmarshal(bytes.NewReader(bin), uint64(len(bin))+boxHeaderSize, &dst)
+	require.NoError(t, err)
+	assert.Equal(t, n, n2)
+	assert.Equal(t, src, dst)
+}
+
+type endianExtendBox struct {
+	mockBox
+	FullBox `mp4:"extend"`
+
+	Inherited uint16          `mp4:"size=16,endian=le"`
+	Nested    endianNestedExt `mp4:"extend,endian=le"`
+}
+
+type endianNestedExt struct {
+	A uint16 `mp4:"size=16"`           // inherits le from the enclosing extend tag
+	B uint16 `mp4:"size=16,endian=be"` // explicit override wins
+}
+
+func TestEndianInheritedThroughExtend(t *testing.T) {
+	boxType := StrToBoxType("uui2")
+	mb := mockBox{Type: boxType}
+	AddBoxDef(&endianExtendBox{mockBox: mb}, 0)
+
+	src := endianExtendBox{
+		mockBox:   mb,
+		Inherited: 0x0102,
+		Nested:    endianNestedExt{A: 0x0304, B: 0x0506},
+	}
+
+	buf := &bytes.Buffer{}
+	_, err := Marshal(buf, &src)
+	require.NoError(t, err)
+
+	bin := buf.Bytes()
+	body := bin[4:] // skip version+flags
+	assert.Equal(t, []byte{0x02, 0x01}, body[0:2], "Inherited written little-endian")
+	assert.Equal(t, []byte{0x04, 0x03}, body[2:4], "A inherits little-endian from Nested's extend tag")
+	assert.Equal(t, []byte{0x05, 0x06}, body[4:6], "B keeps its explicit big-endian override")
+
+	dst := endianExtendBox{mockBox: mb}
+	_, err = Unmarshal(bytes.NewReader(bin), uint64(len(bin))+boxHeaderSize, &dst)
+	require.NoError(t, err)
+	assert.Equal(t, src, dst)
+}
@@ -0,0 +1,303 @@
+package mp4
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// anyVersion is the sentinel FieldConfig.version/nVersion value meaning "not
+// restricted to a particular FullBox version".
+const anyVersion = -1
+
+// lengthUnlimited is the sentinel FieldConfig.length value meaning "no
+// numeric len= tag was given" (the tag is absent, or is len=dynamic, see
+// lengthDynamic).
+const lengthUnlimited = ^uint(0)
+
+// stringType selects how a `string` tag field is framed on the wire.
+type stringType int
+
+const (
+	// StringType_C is a plain C-style, NUL-terminated string.
+	StringType_C stringType = iota
+
+	// StringType_C_P is a NUL-terminated string on marshal. Unmarshal also
+	// reads it as a NUL-terminated string only; a Pascal-style
+	// (length-prefixed) string is not recognised.
+	StringType_C_P
+)
+
+// FieldConfig is the resolved, typed form of a field's `mp4:"..."` tag. It
+// is passed to FieldPacker implementations so a field type defined outside
+// this package can still read tag data relevant to packing/unpacking
+// itself; its fields are unexported, so use the accessor methods below.
+type FieldConfig struct {
+	name string
+	cfo  IImmutableBox
+
+	size   uint
+	length uint
+
+	// lengthDynamic marks a `len=dynamic` field whose length wasn't resolved
+	// eagerly in readFieldConfig (unlike optDynamic's IsOptFieldEnabled,
+	// resolving cfo.GetFieldLength here would force the sizeof/lenof count
+	// field to have already been processed, which fails whenever the count field
+	// is declared after the slice it describes). Marshal never needs to
+	// resolve it at all (the slice's own Len() is the ground truth);
+	// Unmarshal resolves it lazily, in unmarshalField, at the point the
+	// slice's length is actually needed.
+	lengthDynamic bool
+
+	version  int
+	nVersion int
+
+	varint bool
+
+	optFlag    uint32
+	nOptFlag   uint32
+	optDynamic bool
+
+	cnst string
+
+	extend bool
+	hex    bool
+
+	str     bool
+	strType stringType
+
+	iso639_2 bool
+
+	// sizeofTarget/lenofTarget name another field in the same struct whose
+	// element count (sizeof) or byte length (lenof) this field carries.
+	// Marshal accepts this field (the count) in any position relative to
+	// its target, since the target's own length is always already known.
+	// Unmarshal needs the count's value before it can read the target, so
+	// it must come first on the wire if the target uses `len=dynamic`.
+	sizeofTarget string
+	lenofTarget  string
+
+	// endian is this field's explicit `endian=le`/`endian=be` override, or
+	// endianInherit if the tag was not present.
+	endian byteOrder
+
+	// float16 marks a `float16` field: an IEEE 754 binary16 packed into 16
+	// bits (a size= tag other than 16 is rejected) and exposed as a Go
+	// float32/64.
+	float16 bool
+
+	// fixed marks a `fixed=I.F` field, with fixedFrac the fractional width
+	// F; the integer width I is cfg.size-fixedFrac. The field is exposed as
+	// a Go float32/64, decoded as a signed two's-complement fixed-point
+	// number.
+	fixed     bool
+	fixedFrac uint
+}
+
+// Name returns the struct field's name.
+func (cfg FieldConfig) Name() string {
+	return cfg.name
+}
+
+// Size returns the field's declared `size=` bit width, or 0 if it has none.
+func (cfg FieldConfig) Size() uint {
+	return cfg.size
+}
+
+// readFieldConfig resolves tag into a FieldConfig, consulting cfo for a
+// `size=dynamic` value. A `len=dynamic` tag is only flagged here (see
+// lengthDynamic), not resolved.
+func readFieldConfig(cfo IImmutableBox, v reflect.Value, name string, tag fieldTag) (FieldConfig, error) {
+	cfg := FieldConfig{
+		name:     name,
+		cfo:      cfo,
+		length:   lengthUnlimited,
+		version:  anyVersion,
+		nVersion: anyVersion,
+	}
+
+	if s, ok := tag["size"]; ok {
+		if s == "dynamic" {
+			cfg.size = cfo.GetFieldSize(name)
+		} else {
+			n, err := strconv.ParseUint(s, 10, 8)
+			if err != nil {
+				return FieldConfig{}, fmt.Errorf("invalid size tag of field %s: %s", name, s)
+			}
+			cfg.size = uint(n)
+		}
+	}
+
+	if l, ok := tag["len"]; ok {
+		if l == "dynamic" {
+			cfg.lengthDynamic = true
+		} else {
+			n, err := strconv.ParseUint(l, 10, 64)
+			if err != nil {
+				return FieldConfig{}, fmt.Errorf("invalid len tag of field %s: %s", name, l)
+			}
+			cfg.length = uint(n)
+		}
+	}
+
+	if _, ok := tag["varint"]; ok {
+		cfg.varint = true
+	}
+
+	if ver, ok := tag["ver"]; ok {
+		n, err := strconv.ParseUint(ver, 10, 8)
+		if err != nil {
+			return FieldConfig{}, fmt.Errorf("invalid ver tag of field %s: %s", name, ver)
+		}
+		cfg.version = int(n)
+	}
+
+	if nver, ok := tag["nver"]; ok {
+		n, err := strconv.ParseUint(nver, 10, 8)
+		if err != nil {
+			return FieldConfig{}, fmt.Errorf("invalid nver tag of field %s: %s", name, nver)
+		}
+		cfg.nVersion = int(n)
+	}
+
+	if opt, ok := tag["opt"]; ok {
+		if opt == "dynamic" {
+			cfg.optDynamic = true
+		} else {
+			flag, err := parseFlag(opt)
+			if err != nil {
+				return FieldConfig{}, fmt.Errorf("invalid opt tag of field %s: %s", name, opt)
+			}
+			cfg.optFlag = flag
+		}
+	}
+
+	if nopt, ok := tag["nopt"]; ok {
+		flag, err := parseFlag(nopt)
+		if err != nil {
+			return FieldConfig{}, fmt.Errorf("invalid nopt tag of field %s: %s", name, nopt)
+		}
+		cfg.nOptFlag = flag
+	}
+
+	if cnst, ok := tag["const"]; ok {
+		cfg.cnst = cnst
+	}
+
+	if _, ok := tag["extend"]; ok {
+		cfg.extend = true
+	}
+
+	if _, ok := tag["hex"]; ok {
+		cfg.hex = true
+	}
+
+	if str, ok := tag["string"]; ok {
+		cfg.str = true
+		switch str {
+		case "", "c":
+			cfg.strType = StringType_C
+		case "c_p":
+			cfg.strType = StringType_C_P
+		default:
+			return FieldConfig{}, fmt.Errorf("invalid string tag of field %s: %s", name, str)
+		}
+	}
+
+	if _, ok := tag["iso639-2"]; ok {
+		cfg.iso639_2 = true
+	}
+
+	if target, ok := tag["sizeof"]; ok {
+		cfg.sizeofTarget = target
+	}
+
+	if target, ok := tag["lenof"]; ok {
+		cfg.lenofTarget = target
+	}
+
+	if e, ok := tag["endian"]; ok {
+		switch e {
+		case "le":
+			cfg.endian = endianLittle
+		case "be":
+			cfg.endian = endianBig
+		default:
+			return FieldConfig{}, fmt.Errorf("invalid endian tag of field %s: %s", name, e)
+		}
+	}
+
+	if _, ok := tag["float16"]; ok {
+		cfg.float16 = true
+		if cfg.size == 0 {
+			cfg.size = 16
+		} else if cfg.size != 16 {
+			return FieldConfig{}, fmt.Errorf("float16 tag of field %s doesn't support size=%d", name, cfg.size)
+		}
+	}
+
+	if fixed, ok := tag["fixed"]; ok {
+		intBits, fracBits, err := parseFixedWidths(fixed)
+		if err != nil {
+			return FieldConfig{}, fmt.Errorf("invalid fixed tag of field %s: %s", name, fixed)
+		}
+		cfg.fixed = true
+		cfg.fixedFrac = fracBits
+		if cfg.size == 0 {
+			cfg.size = intBits + fracBits
+		} else if cfg.size != intBits+fracBits {
+			return FieldConfig{}, fmt.Errorf("fixed tag %s of field %s doesn't match size=%d", fixed, name, cfg.size)
+		}
+	}
+
+	return cfg, nil
+}
+
+// parseFixedWidths splits a `fixed=I.F` tag value into its integer and
+// fractional bit widths.
+func parseFixedWidths(s string) (intBits, fracBits uint, err error) {
+	i := strings.IndexByte(s, '.')
+	if i < 0 {
+		return 0, 0, fmt.Errorf("missing '.'")
+	}
+	in, err := strconv.ParseUint(s[:i], 10, 8)
+	if err != nil {
+		return 0, 0, err
+	}
+	frac, err := strconv.ParseUint(s[i+1:], 10, 8)
+	if err != nil {
+		return 0, 0, err
+	}
+	return uint(in), uint(frac), nil
+}
+
+func parseFlag(s string) (uint32, error) {
+	if strings.HasPrefix(s, "0x") {
+		n, err := strconv.ParseUint(s[2:], 16, 32)
+		return uint32(n), err
+	}
+	n, err := strconv.ParseUint(s, 10, 32)
+	return uint32(n), err
+}
+
+// fieldEnabled reports whether a field described by cfg should be read or
+// written at all, given the current box version and flags.
+func fieldEnabled(cfg FieldConfig) bool {
+	if cfg.version != anyVersion && cfg.cfo.GetVersion() != uint8(cfg.version) {
+		return false
+	}
+	if cfg.nVersion != anyVersion && cfg.cfo.GetVersion() == uint8(cfg.nVersion) {
+		return false
+	}
+	if cfg.optDynamic {
+		return cfg.cfo.IsOptFieldEnabled(cfg.name)
+	}
+	if cfg.optFlag != 0 && !cfg.cfo.CheckFlag(cfg.optFlag) {
+		return false
+	}
+	if cfg.nOptFlag != 0 && cfg.cfo.CheckFlag(cfg.nOptFlag) {
+		return false
+	}
+	return true
+}
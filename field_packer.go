@@ -0,0 +1,41 @@
+package mp4
+
+import (
+	"reflect"
+
+	"github.com/abema/go-mp4/bitio"
+)
+
+// FieldPacker lets a struct field take full control of how it is packed and
+// unpacked, instead of going through the built-in reflect-based field
+// handling. A field opts in by having its type (or a pointer to its type)
+// implement FieldPacker; its `mp4` tag is still parsed as usual and passed
+// through via cfg, so codec-specific value types (NAL unit arrays,
+// parameter sets, compressed run-length tables, ...) can be declared as
+// plain fields on a box struct instead of driving a per-box
+// OnReadField/OnWriteField state machine. A FieldPacker that also wants to
+// format for display can implement fmt.Stringer directly, the same as any
+// other Go type.
+type FieldPacker interface {
+	// MP4Pack writes the field's wire representation to w and returns the
+	// number of bits written.
+	MP4Pack(w bitio.Writer, cfg FieldConfig) (uint64, error)
+
+	// MP4Unpack reads the field's wire representation from r, which has at
+	// most size bits remaining, and returns the number of bits consumed.
+	MP4Unpack(r bitio.ReadSeeker, cfg FieldConfig, size uint64) (uint64, error)
+}
+
+// asFieldPacker returns v as a FieldPacker, trying v.Addr() when only the
+// pointer type implements the interface. ok is false when neither does.
+func asFieldPacker(v reflect.Value) (fp FieldPacker, ok bool) {
+	if fp, ok := v.Interface().(FieldPacker); ok {
+		return fp, true
+	}
+	if v.CanAddr() {
+		if fp, ok := v.Addr().Interface().(FieldPacker); ok {
+			return fp, true
+		}
+	}
+	return nil, false
+}
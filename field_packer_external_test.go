@@ -0,0 +1,47 @@
+package mp4_test
+
+import (
+	"bytes"
+	"testing"
+
+	mp4 "github.com/abema/go-mp4"
+	"github.com/abema/go-mp4/bitio"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// halfUint16 is a minimal FieldPacker defined outside package mp4, proving
+// FieldConfig's exported surface (a mp4.FieldConfig value and no unexported
+// identifiers) is all a downstream type needs to implement the interface.
+type halfUint16 uint16
+
+func (v halfUint16) MP4Pack(w bitio.Writer, cfg mp4.FieldConfig) (uint64, error) {
+	if err := w.WriteBits(uint64(v), 16); err != nil {
+		return 0, err
+	}
+	return 16, nil
+}
+
+func (v *halfUint16) MP4Unpack(r bitio.ReadSeeker, cfg mp4.FieldConfig, size uint64) (uint64, error) {
+	n, err := r.ReadBits(16)
+	if err != nil {
+		return 0, err
+	}
+	*v = halfUint16(n)
+	return 16, nil
+}
+
+func TestExternalFieldPackerPackUnpack(t *testing.T) {
+	src := halfUint16(0x1234)
+
+	buf := &bytes.Buffer{}
+	n, err := src.MP4Pack(bitio.NewWriter(buf), mp4.FieldConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(16), n)
+
+	var dst halfUint16
+	n, err = dst.MP4Unpack(bitio.NewReadSeeker(bytes.NewReader(buf.Bytes())), mp4.FieldConfig{}, 16)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(16), n)
+	assert.Equal(t, src, dst)
+}
@@ -0,0 +1,79 @@
+package mp4
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/abema/go-mp4/bitio"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFixed16_16(t *testing.T) {
+	testCases := []struct {
+		name string
+		raw  Fixed16_16
+		f    float64
+		str  string
+	}{
+		{name: "one", raw: 0x00010000, f: 1, str: "1"},
+		{name: "negative", raw: -0x00018000, f: -1.5, str: "-1.5"},
+		{name: "zero", raw: 0, f: 0, str: "0"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.f, tc.raw.Float64())
+			assert.Equal(t, tc.str, tc.raw.String())
+		})
+	}
+}
+
+func TestFixed16_16PackUnpack(t *testing.T) {
+	src := Fixed16_16(-0x00018000)
+
+	buf := &bytes.Buffer{}
+	n, err := src.MP4Pack(bitio.NewWriter(buf), FieldConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(32), n)
+
+	var dst Fixed16_16
+	n, err = dst.MP4Unpack(bitio.NewReadSeeker(bytes.NewReader(buf.Bytes())), FieldConfig{}, 32)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(32), n)
+	assert.Equal(t, src, dst)
+}
+
+func TestFixed8_8(t *testing.T) {
+	v := Fixed8_8(0x0180)
+	assert.Equal(t, 1.5, v.Float64())
+	assert.Equal(t, "1.5", v.String())
+}
+
+type fieldPackerTestBox struct {
+	mockBox
+	FullBox `mp4:"extend"`
+	Rate    Fixed16_16 `mp4:"size=32"`
+	Volume  Fixed8_8   `mp4:"size=16"`
+}
+
+func TestFieldPackerMarshalUnmarshal(t *testing.T) {
+	mb := mockBox{Type: StrToBoxType("tst2")}
+	AddBoxDef(&fieldPackerTestBox{mockBox: mb}, 0)
+
+	src := fieldPackerTestBox{
+		mockBox: mb,
+		Rate:    0x00010000,
+		Volume:  0x0100,
+	}
+
+	buf := &bytes.Buffer{}
+	n, err := Marshal(buf, &src)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(10), n) // version(1) + flags(3) + rate(4) + volume(2)
+
+	dst := fieldPackerTestBox{mockBox: mb}
+	n2, err := Unmarshal(bytes.NewReader(buf.Bytes()), uint64(buf.Len())+boxHeaderSize, &dst)
+	require.NoError(t, err)
+	assert.Equal(t, n, n2)
+	assert.Equal(t, src, dst)
+}
@@ -0,0 +1,100 @@
+package mp4
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/abema/go-mp4/bitio"
+)
+
+// Fixed16_16 is a signed 16.16 fixed-point number, the representation used
+// by e.g. tkhd's transformation matrix and mvhd's rate. It implements
+// FieldPacker so boxes can declare it directly as a field type instead of a
+// raw int32 that every caller has to reinterpret.
+type Fixed16_16 int32
+
+const fixed16_16Frac = 16
+
+// Float64 returns v as a floating point number.
+func (v Fixed16_16) Float64() float64 {
+	return float64(v) / float64(int64(1)<<fixed16_16Frac)
+}
+
+// MP4Pack implements FieldPacker.
+func (v Fixed16_16) MP4Pack(w bitio.Writer, cfg FieldConfig) (uint64, error) {
+	if err := w.WriteBits(uint64(uint32(v)), 32); err != nil {
+		return 0, err
+	}
+	return 32, nil
+}
+
+// MP4Unpack implements FieldPacker.
+func (v *Fixed16_16) MP4Unpack(r bitio.ReadSeeker, cfg FieldConfig, size uint64) (uint64, error) {
+	n, err := r.ReadBits(32)
+	if err != nil {
+		return 0, err
+	}
+	*v = Fixed16_16(int32(uint32(n)))
+	return 32, nil
+}
+
+// String implements fmt.Stringer.
+func (v Fixed16_16) String() string {
+	return fmt.Sprintf("%g", v.Float64())
+}
+
+// Fixed8_8 is a signed 8.8 fixed-point number, used e.g. by mvhd's volume.
+type Fixed8_8 int16
+
+const fixed8_8Frac = 8
+
+// Float64 returns v as a floating point number.
+func (v Fixed8_8) Float64() float64 {
+	return float64(v) / float64(int64(1)<<fixed8_8Frac)
+}
+
+// MP4Pack implements FieldPacker.
+func (v Fixed8_8) MP4Pack(w bitio.Writer, cfg FieldConfig) (uint64, error) {
+	if err := w.WriteBits(uint64(uint16(v)), 16); err != nil {
+		return 0, err
+	}
+	return 16, nil
+}
+
+// MP4Unpack implements FieldPacker.
+func (v *Fixed8_8) MP4Unpack(r bitio.ReadSeeker, cfg FieldConfig, size uint64) (uint64, error) {
+	n, err := r.ReadBits(16)
+	if err != nil {
+		return 0, err
+	}
+	*v = Fixed8_8(int16(uint16(n)))
+	return 16, nil
+}
+
+// String implements fmt.Stringer.
+func (v Fixed8_8) String() string {
+	return fmt.Sprintf("%g", v.Float64())
+}
+
+// encodeFixed converts v into a size-bit signed two's-complement
+// fixed-point number with fracBits fractional bits, rounding to the nearest
+// representable value and saturating on overflow. It backs the generic
+// `fixed=I.F` tag, which -- unlike Fixed16_16/Fixed8_8 -- lets a plain
+// float64 field use a fixed-point width those named types don't cover (e.g.
+// a 2.30 colour primary).
+func encodeFixed(v float64, size, fracBits uint) uint64 {
+	scaled := math.Round(v * float64(int64(1)<<fracBits))
+	max := float64(int64(1)<<(size-1) - 1)
+	min := -float64(int64(1) << (size - 1))
+	if scaled > max {
+		scaled = max
+	} else if scaled < min {
+		scaled = min
+	}
+	return uint64(int64(scaled)) & mask(size)
+}
+
+// decodeFixed is the inverse of encodeFixed.
+func decodeFixed(raw uint64, size, fracBits uint) float64 {
+	return float64(signExtend(raw, size)) / float64(int64(1)<<fracBits)
+}
@@ -0,0 +1,72 @@
+package mp4
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFixedTagRoundTrip(t *testing.T) {
+	testCases := []struct {
+		name           string
+		size, fracBits uint
+		raw            uint64
+		f              float64
+	}{
+		{name: "2.30 one", size: 32, fracBits: 30, raw: 0x40000000, f: 1},
+		{name: "2.30 negative", size: 32, fracBits: 30, raw: 0xc0000000, f: -1},
+		{name: "16.16 one point five", size: 32, fracBits: 16, raw: 0x00018000, f: 1.5},
+		{name: "8.8 negative one point five", size: 16, fracBits: 8, raw: 0xfe80, f: -1.5},
+		{name: "zero", size: 32, fracBits: 16, raw: 0, f: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.f, decodeFixed(tc.raw, tc.size, tc.fracBits))
+			assert.Equal(t, tc.raw, encodeFixed(tc.f, tc.size, tc.fracBits))
+		})
+	}
+}
+
+func TestFixedTagSaturatesOnOverflow(t *testing.T) {
+	assert.Equal(t, uint64(0x7fffffff), encodeFixed(1e9, 32, 30))
+	assert.Equal(t, uint64(0x80000000), encodeFixed(-1e9, 32, 30))
+}
+
+type fixedTagTestBox struct {
+	mockBox
+	FullBox `mp4:"extend"`
+	Primary float64 `mp4:"fixed=2.30"`
+}
+
+func TestFixedTagMarshalUnmarshal(t *testing.T) {
+	mb := mockBox{Type: StrToBoxType("tst6")}
+	AddBoxDef(&fixedTagTestBox{mockBox: mb}, 0)
+
+	src := fixedTagTestBox{mockBox: mb, Primary: -0.5}
+
+	buf := &bytes.Buffer{}
+	_, err := Marshal(buf, &src)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xe0, 0x00, 0x00, 0x00}, buf.Bytes()[4:8])
+
+	dst := fixedTagTestBox{mockBox: mb}
+	_, err = Unmarshal(bytes.NewReader(buf.Bytes()), uint64(buf.Len())+boxHeaderSize, &dst)
+	require.NoError(t, err)
+	assert.Equal(t, src, dst)
+}
+
+func TestFixedTagSizeMismatchIsRejected(t *testing.T) {
+	type badBox struct {
+		mockBox
+		FullBox `mp4:"extend"`
+		V       float64 `mp4:"size=16,fixed=2.30"`
+	}
+	mb := mockBox{Type: StrToBoxType("tst7")}
+	AddBoxDef(&badBox{mockBox: mb}, 0)
+
+	buf := &bytes.Buffer{}
+	_, err := Marshal(buf, &badBox{mockBox: mb})
+	assert.Error(t, err)
+}
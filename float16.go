@@ -0,0 +1,76 @@
+package mp4
+
+import "math"
+
+// encodeFloat16 converts v to IEEE 754 binary16 (1 sign / 5 exponent / 10
+// mantissa bit), rounding to nearest-even and clamping to +/-inf on
+// overflow. It backs the `float16` tag, used by fields (e.g. forthcoming
+// HDR metadata) that are natively half-precision on the wire but are
+// exposed to callers as an ordinary Go float32/float64.
+func encodeFloat16(v float32) uint16 {
+	bits := math.Float32bits(v)
+	sign := uint16((bits >> 16) & 0x8000)
+	exp := int32((bits>>23)&0xff) - 127
+	mant := bits & 0x7fffff
+
+	switch {
+	case exp == 128: // inf or NaN
+		if mant != 0 {
+			return sign | 0x7c00 | 0x0200 // NaN, quiet
+		}
+		return sign | 0x7c00
+	case exp > 15: // overflow
+		return sign | 0x7c00
+	case exp >= -14: // normal
+		// Round the 23-bit mantissa down to 10 bits, nearest-even.
+		halfMant := mant >> 13
+		roundBit := mant & 0x1000
+		if roundBit != 0 && (mant&0xfff != 0 || halfMant&1 != 0) {
+			halfMant++
+		}
+		halfExp := uint16(exp+15) << 10
+		if halfMant == 0x400 { // mantissa rounded up into the exponent
+			halfMant = 0
+			halfExp += 0x400
+		}
+		return sign | halfExp | uint16(halfMant)
+	case exp >= -25: // subnormal, or close enough to round up into one
+		mant |= 0x800000 // restore the implicit leading 1
+		shift := uint(-exp - 14 + 13)
+		halfMant := mant >> shift
+		roundBit := uint32(1) << (shift - 1)
+		if mant&roundBit != 0 && (mant&(roundBit-1) != 0 || halfMant&1 != 0) {
+			halfMant++
+		}
+		return sign | uint16(halfMant)
+	default: // underflow
+		return sign
+	}
+}
+
+// decodeFloat16 is the inverse of encodeFloat16.
+func decodeFloat16(raw uint16) float32 {
+	sign := raw & 0x8000
+	exp := (raw >> 10) & 0x1f
+	mant := raw & 0x3ff
+
+	var bits uint32
+	switch {
+	case exp == 0x1f: // inf or NaN
+		bits = uint32(mant) << 13
+		bits |= 0xff << 23
+	case exp == 0: // zero or subnormal
+		if mant == 0 {
+			bits = 0
+		} else {
+			// mantissa * 2^-24, re-biased into a normal float32.
+			f := float32(mant) * float32(math.Pow(2, -24))
+			bits = math.Float32bits(f)
+		}
+	default: // normal: re-bias from a 15-exponent to a 127-exponent.
+		bits = (uint32(exp) + 112) << 23
+		bits |= uint32(mant) << 13
+	}
+	bits |= uint32(sign) << 16
+	return math.Float32frombits(bits)
+}
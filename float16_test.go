@@ -0,0 +1,104 @@
+package mp4
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFloat16RoundTrip(t *testing.T) {
+	testCases := []struct {
+		name string
+		raw  uint16
+		f    float32
+	}{
+		{name: "zero", raw: 0x0000, f: 0},
+		{name: "negative zero", raw: 0x8000, f: float32(math.Copysign(0, -1))},
+		{name: "one", raw: 0x3c00, f: 1},
+		{name: "negative one point five", raw: 0xbe00, f: -1.5},
+		{name: "smallest subnormal", raw: 0x0001, f: float32(math.Pow(2, -24))},
+		{name: "largest subnormal", raw: 0x03ff, f: float32(1023) * float32(math.Pow(2, -24))},
+		{name: "largest normal", raw: 0x7bff, f: 65504},
+		{name: "smallest normal", raw: 0x0400, f: float32(math.Pow(2, -14))},
+		{name: "inf", raw: 0x7c00, f: float32(math.Inf(1))},
+		{name: "negative inf", raw: 0xfc00, f: float32(math.Inf(-1))},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.f, decodeFloat16(tc.raw))
+			assert.Equal(t, tc.raw, encodeFloat16(tc.f))
+		})
+	}
+}
+
+func TestFloat16NaN(t *testing.T) {
+	assert.True(t, math.IsNaN(float64(decodeFloat16(0x7e00))))
+	assert.True(t, math.IsNaN(float64(decodeFloat16(0xfe00))))
+	assert.True(t, math.IsNaN(float64(decodeFloat16(encodeFloat16(float32(math.NaN()))))))
+}
+
+func TestFloat16OverflowSaturatesToInf(t *testing.T) {
+	assert.Equal(t, uint16(0x7c00), encodeFloat16(math.MaxFloat32))
+	assert.Equal(t, uint16(0xfc00), encodeFloat16(-math.MaxFloat32))
+}
+
+func TestFloat16UnderflowSaturatesToZero(t *testing.T) {
+	assert.Equal(t, uint16(0x0000), encodeFloat16(float32(math.Pow(2, -30))))
+	assert.Equal(t, uint16(0x8000), encodeFloat16(float32(-math.Pow(2, -30))))
+}
+
+// TestFloat16RoundsUpIntoSmallestSubnormal covers the boundary just below
+// the smallest subnormal (2^-24): values strictly above its half (2^-25)
+// are closer to 0x0001 than to 0 and must round up to it, rather than
+// underflowing to 0.
+func TestFloat16RoundsUpIntoSmallestSubnormal(t *testing.T) {
+	// 5.9287114e-08 is strictly between 2^-25 and 2^-24.
+	assert.Equal(t, uint16(0x0001), encodeFloat16(5.9287114e-08))
+	assert.Equal(t, uint16(0x8001), encodeFloat16(-5.9287114e-08))
+
+	// Exactly halfway between 0 and 0x0001: ties round to even, i.e. 0.
+	assert.Equal(t, uint16(0x0000), encodeFloat16(float32(math.Pow(2, -25))))
+
+	// Just below the halfway point still underflows to 0.
+	assert.Equal(t, uint16(0x0000), encodeFloat16(float32(math.Pow(2, -25))*0.99))
+}
+
+func TestFloat16TagSizeMismatchIsRejected(t *testing.T) {
+	type badBox struct {
+		mockBox
+		FullBox `mp4:"extend"`
+		V       float32 `mp4:"size=8,float16"`
+	}
+	mb := mockBox{Type: StrToBoxType("tst8")}
+	AddBoxDef(&badBox{mockBox: mb}, 0)
+
+	buf := &bytes.Buffer{}
+	_, err := Marshal(buf, &badBox{mockBox: mb})
+	assert.Error(t, err)
+}
+
+type float16TestBox struct {
+	mockBox
+	FullBox `mp4:"extend"`
+	Value   float32 `mp4:"size=16,float16"`
+}
+
+func TestFloat16MarshalUnmarshal(t *testing.T) {
+	mb := mockBox{Type: StrToBoxType("tst5")}
+	AddBoxDef(&float16TestBox{mockBox: mb}, 0)
+
+	src := float16TestBox{mockBox: mb, Value: -1.5}
+
+	buf := &bytes.Buffer{}
+	_, err := Marshal(buf, &src)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xbe, 0x00}, buf.Bytes()[4:6])
+
+	dst := float16TestBox{mockBox: mb}
+	_, err = Unmarshal(bytes.NewReader(buf.Bytes()), uint64(buf.Len())+boxHeaderSize, &dst)
+	require.NoError(t, err)
+	assert.Equal(t, src, dst)
+}
@@ -0,0 +1,238 @@
+package mp4
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+
+	"github.com/abema/go-mp4/bitio"
+)
+
+// Marshal writes the binary payload of box (without its 8/16-byte box
+// header) to w and returns the number of bytes written.
+func Marshal(w io.Writer, box IImmutableBox) (uint64, error) {
+	m := &marshaller{writer: bitio.NewWriter(w), dynLen: make(map[string]uint)}
+	cfo := IImmutableBox(&dynLenBox{IImmutableBox: box, dynLen: m.dynLen})
+	if err := m.marshalStruct(cfo, reflect.ValueOf(box).Elem(), false); err != nil {
+		return 0, err
+	}
+	m.wbits += m.writer.Align()
+	return m.wbits / 8, nil
+}
+
+type marshaller struct {
+	writer bitio.Writer
+	wbits  uint64
+
+	// dynLen records the element count of each sizeof/lenof target, keyed
+	// by the target field's name, and backs cfo's GetFieldLength for that
+	// name. Marshal's own slice handling does not read it: a len=dynamic
+	// slice is written with its own Len().
+	dynLen map[string]uint
+}
+
+func (m *marshaller) marshalStruct(cfo IImmutableBox, v reflect.Value, le bool) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tagStr, ok := sf.Tag.Lookup("mp4")
+		if !ok {
+			continue
+		}
+		cfg, err := readFieldConfig(cfo, v, sf.Name, parseFieldTag(tagStr))
+		if err != nil {
+			return err
+		}
+		if !fieldEnabled(cfg) {
+			continue
+		}
+		if cfg.sizeofTarget != "" {
+			n := sliceElemCount(v.FieldByName(cfg.sizeofTarget))
+			countField(v.Field(i), n)
+			m.dynLen[cfg.sizeofTarget] = uint(n)
+		}
+		if cfg.lenofTarget != "" {
+			elemBits := fieldSizeBits(v, cfg.lenofTarget)
+			if elemBits == 0 {
+				return fmt.Errorf("lenof target %s of field %s needs a static size tag", cfg.lenofTarget, sf.Name)
+			}
+			target := v.FieldByName(cfg.lenofTarget)
+			countField(v.Field(i), sliceByteLength(target, elemBits))
+			// dynLen holds element counts (what len=dynamic resolves to),
+			// not byte lengths, so store the target's own element count here
+			// rather than the byte length just written to the wire.
+			m.dynLen[cfg.lenofTarget] = uint(target.Len())
+		}
+		if err := m.marshalField(cfo, v.Field(i), cfg, cfg.endian.resolve(le)); err != nil {
+			return fmt.Errorf("failed to marshal field %s: %w", sf.Name, err)
+		}
+	}
+	return nil
+}
+
+func (m *marshaller) marshalField(cfo IImmutableBox, v reflect.Value, cfg FieldConfig, le bool) error {
+	if n, override, err := cfo.OnWriteField(cfg.name, m.writer); err != nil {
+		return err
+	} else if override {
+		m.wbits += n
+		return nil
+	}
+
+	if fp, ok := asFieldPacker(v); ok {
+		n, err := fp.MP4Pack(m.writer, cfg)
+		if err != nil {
+			return err
+		}
+		m.wbits += n
+		return nil
+	}
+
+	if cfg.extend {
+		ev := v
+		if ev.Kind() == reflect.Ptr {
+			ev = ev.Elem()
+		}
+		return m.marshalStruct(cfo, ev, le)
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		return m.marshalField(cfo, v.Elem(), cfg, le)
+	case reflect.Struct:
+		return m.marshalStruct(cfo, v, le)
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := m.marshalValue(v.Index(i), cfg, le); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Slice:
+		l := v.Len()
+		if cfg.length != lengthUnlimited {
+			l = int(cfg.length)
+		}
+		for i := 0; i < l; i++ {
+			if err := m.marshalValue(v.Index(i), cfg, le); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.String:
+		return m.marshalString(v.String())
+	default:
+		return m.marshalValue(v, cfg, le)
+	}
+}
+
+func (m *marshaller) marshalString(s string) error {
+	for i := 0; i < len(s); i++ {
+		if err := m.writer.WriteByte(s[i]); err != nil {
+			return err
+		}
+		m.wbits += 8
+	}
+	if err := m.writer.WriteByte(0); err != nil {
+		return err
+	}
+	m.wbits += 8
+	return nil
+}
+
+func (m *marshaller) marshalValue(v reflect.Value, cfg FieldConfig, le bool) error {
+	if cfg.cnst != "" {
+		n, err := strconv.ParseUint(cfg.cnst, 0, 64)
+		if err != nil {
+			return fmt.Errorf("invalid const tag: %s", cfg.cnst)
+		}
+		return m.writeInt(n, cfg.size, le)
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		n := uint64(0)
+		if v.Bool() {
+			n = 1
+		}
+		return m.writeBits(n, uint64(cfg.size))
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
+		return m.marshalUint(uint64(v.Int())&mask(cfg.size), cfg, le)
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
+		return m.marshalUint(v.Uint(), cfg, le)
+	case reflect.Float32, reflect.Float64:
+		return m.marshalFloat(v.Float(), cfg, le)
+	default:
+		return fmt.Errorf("unsupported field kind: %s", v.Kind())
+	}
+}
+
+// marshalFloat writes a `float16` or `fixed=I.F` tagged field, converting v
+// to its wire bit pattern first.
+func (m *marshaller) marshalFloat(v float64, cfg FieldConfig, le bool) error {
+	switch {
+	case cfg.float16:
+		return m.writeInt(uint64(encodeFloat16(float32(v))), cfg.size, le)
+	case cfg.fixed:
+		return m.writeInt(encodeFixed(v, cfg.size, cfg.fixedFrac), cfg.size, le)
+	default:
+		return fmt.Errorf("float field %s requires a float16 or fixed tag", cfg.name)
+	}
+}
+
+func (m *marshaller) marshalUint(n uint64, cfg FieldConfig, le bool) error {
+	if cfg.varint {
+		return m.marshalVarint(n)
+	}
+	return m.writeInt(n, cfg.size, le)
+}
+
+func (m *marshaller) marshalVarint(n uint64) error {
+	groups := []uint64{n & 0x7f}
+	for n >>= 7; n != 0; n >>= 7 {
+		groups = append(groups, n&0x7f)
+	}
+	for i := len(groups) - 1; i >= 0; i-- {
+		b := groups[i]
+		if i != 0 {
+			b |= 0x80
+		}
+		if err := m.writer.WriteByte(byte(b)); err != nil {
+			return err
+		}
+		m.wbits += 8
+	}
+	return nil
+}
+
+func (m *marshaller) writeBits(n uint64, size uint64) error {
+	if err := m.writer.WriteBits(n, size); err != nil {
+		return err
+	}
+	m.wbits += size
+	return nil
+}
+
+// writeInt writes an integer field of size bits, byte-swapping it first when
+// le is set and the field is byte-aligned. Sub-byte little-endian fields
+// don't occur in practice, so size%8 != 0 always writes big-endian.
+func (m *marshaller) writeInt(n uint64, size uint, le bool) error {
+	if !le || size%8 != 0 || size == 0 {
+		return m.writeBits(n, uint64(size))
+	}
+	for i := uint(0); i < size; i += 8 {
+		if err := m.writer.WriteByte(byte(n >> i)); err != nil {
+			return err
+		}
+		m.wbits += 8
+	}
+	return nil
+}
+
+func mask(size uint) uint64 {
+	if size >= 64 {
+		return math.MaxUint64
+	}
+	return (uint64(1) << size) - 1
+}
@@ -297,14 +297,14 @@ func TestReadFieldConfig(t *testing.T) {
 		fieldName string
 		fieldTag  fieldTag
 		err       bool
-		expected  fieldConfig
+		expected  FieldConfig
 	}{
 		{
 			name:      "static size",
 			box:       box,
 			fieldName: "ByteArray",
 			fieldTag:  fieldTag{"size": "8"},
-			expected: fieldConfig{
+			expected: FieldConfig{
 				name:     "ByteArray",
 				cfo:      box,
 				size:     8,
@@ -325,7 +325,7 @@ func TestReadFieldConfig(t *testing.T) {
 			box:       box,
 			fieldName: "ByteArray",
 			fieldTag:  fieldTag{"size": "dynamic"},
-			expected: fieldConfig{
+			expected: FieldConfig{
 				name:     "ByteArray",
 				cfo:      box,
 				size:     3,
@@ -339,7 +339,7 @@ func TestReadFieldConfig(t *testing.T) {
 			box:       box,
 			fieldName: "ByteArray",
 			fieldTag:  fieldTag{"len": "16", "size": "8"},
-			expected: fieldConfig{
+			expected: FieldConfig{
 				name:     "ByteArray",
 				cfo:      box,
 				size:     8,
@@ -360,13 +360,14 @@ func TestReadFieldConfig(t *testing.T) {
 			box:       box,
 			fieldName: "ByteArray",
 			fieldTag:  fieldTag{"len": "dynamic", "size": "8"},
-			expected: fieldConfig{
-				name:     "ByteArray",
-				cfo:      box,
-				size:     8,
-				length:   7,
-				version:  anyVersion,
-				nVersion: anyVersion,
+			expected: FieldConfig{
+				name:          "ByteArray",
+				cfo:           box,
+				size:          8,
+				length:        lengthUnlimited,
+				lengthDynamic: true,
+				version:       anyVersion,
+				nVersion:      anyVersion,
 			},
 		},
 		{
@@ -374,7 +375,7 @@ func TestReadFieldConfig(t *testing.T) {
 			box:       box,
 			fieldName: "Int",
 			fieldTag:  fieldTag{"varint": "", "size": "13"},
-			expected: fieldConfig{
+			expected: FieldConfig{
 				name:     "Int",
 				cfo:      box,
 				size:     13,
@@ -389,7 +390,7 @@ func TestReadFieldConfig(t *testing.T) {
 			box:       box,
 			fieldName: "Int",
 			fieldTag:  fieldTag{"ver": "0", "size": "32"},
-			expected: fieldConfig{
+			expected: FieldConfig{
 				name:     "Int",
 				cfo:      box,
 				size:     32,
@@ -403,7 +404,7 @@ func TestReadFieldConfig(t *testing.T) {
 			box:       box,
 			fieldName: "Int",
 			fieldTag:  fieldTag{"ver": "1", "size": "32"},
-			expected: fieldConfig{
+			expected: FieldConfig{
 				name:     "Int",
 				cfo:      box,
 				size:     32,
@@ -424,7 +425,7 @@ func TestReadFieldConfig(t *testing.T) {
 			box:       box,
 			fieldName: "Int",
 			fieldTag:  fieldTag{"nver": "0", "size": "32"},
-			expected: fieldConfig{
+			expected: FieldConfig{
 				name:     "Int",
 				cfo:      box,
 				size:     32,
@@ -438,7 +439,7 @@ func TestReadFieldConfig(t *testing.T) {
 			box:       box,
 			fieldName: "Int",
 			fieldTag:  fieldTag{"nver": "1", "size": "32"},
-			expected: fieldConfig{
+			expected: FieldConfig{
 				name:     "Int",
 				cfo:      box,
 				size:     32,
@@ -459,7 +460,7 @@ func TestReadFieldConfig(t *testing.T) {
 			box:       box,
 			fieldName: "String",
 			fieldTag:  fieldTag{"opt": "dynamic"},
-			expected: fieldConfig{
+			expected: FieldConfig{
 				name:       "String",
 				cfo:        box,
 				length:     lengthUnlimited,
@@ -473,7 +474,7 @@ func TestReadFieldConfig(t *testing.T) {
 			box:       box,
 			fieldName: "String",
 			fieldTag:  fieldTag{"opt": "0x0100"},
-			expected: fieldConfig{
+			expected: FieldConfig{
 				name:     "String",
 				cfo:      box,
 				length:   lengthUnlimited,
@@ -487,7 +488,7 @@ func TestReadFieldConfig(t *testing.T) {
 			box:       box,
 			fieldName: "String",
 			fieldTag:  fieldTag{"opt": "32"},
-			expected: fieldConfig{
+			expected: FieldConfig{
 				name:     "String",
 				cfo:      box,
 				length:   lengthUnlimited,
@@ -508,7 +509,7 @@ func TestReadFieldConfig(t *testing.T) {
 			box:       box,
 			fieldName: "String",
 			fieldTag:  fieldTag{"nopt": "0x0100"},
-			expected: fieldConfig{
+			expected: FieldConfig{
 				name:     "String",
 				cfo:      box,
 				length:   lengthUnlimited,
@@ -522,7 +523,7 @@ func TestReadFieldConfig(t *testing.T) {
 			box:       box,
 			fieldName: "String",
 			fieldTag:  fieldTag{"nopt": "32"},
-			expected: fieldConfig{
+			expected: FieldConfig{
 				name:     "String",
 				cfo:      box,
 				length:   lengthUnlimited,
@@ -543,7 +544,7 @@ func TestReadFieldConfig(t *testing.T) {
 			box:       box,
 			fieldName: "Int",
 			fieldTag:  fieldTag{"const": "0", "size": "32"},
-			expected: fieldConfig{
+			expected: FieldConfig{
 				name:     "Int",
 				cfo:      box,
 				size:     32,
@@ -558,7 +559,7 @@ func TestReadFieldConfig(t *testing.T) {
 			box:       box,
 			fieldName: "FullBox",
 			fieldTag:  fieldTag{"extend": ""},
-			expected: fieldConfig{
+			expected: FieldConfig{
 				name:     "FullBox",
 				cfo:      box,
 				length:   lengthUnlimited,
@@ -572,7 +573,7 @@ func TestReadFieldConfig(t *testing.T) {
 			box:       box,
 			fieldName: "Int",
 			fieldTag:  fieldTag{"hex": "", "size": "32"},
-			expected: fieldConfig{
+			expected: FieldConfig{
 				name:     "Int",
 				cfo:      box,
 				size:     32,
@@ -587,7 +588,7 @@ func TestReadFieldConfig(t *testing.T) {
 			box:       box,
 			fieldName: "String",
 			fieldTag:  fieldTag{"string": ""},
-			expected: fieldConfig{
+			expected: FieldConfig{
 				name:     "String",
 				cfo:      box,
 				length:   lengthUnlimited,
@@ -602,7 +603,7 @@ func TestReadFieldConfig(t *testing.T) {
 			box:       box,
 			fieldName: "String",
 			fieldTag:  fieldTag{"string": "c_p"},
-			expected: fieldConfig{
+			expected: FieldConfig{
 				name:     "String",
 				cfo:      box,
 				length:   lengthUnlimited,
@@ -617,7 +618,7 @@ func TestReadFieldConfig(t *testing.T) {
 			box:       box,
 			fieldName: "String",
 			fieldTag:  fieldTag{"iso639-2": ""},
-			expected: fieldConfig{
+			expected: FieldConfig{
 				name:     "String",
 				cfo:      box,
 				length:   lengthUnlimited,
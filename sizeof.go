@@ -0,0 +1,80 @@
+package mp4
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// dynLenBox wraps an IImmutableBox, resolving `len=dynamic` fields from a
+// sizeof/lenof pairing before falling back to the box's own GetFieldLength.
+// This is what keeps BaseCustomFieldObject.GetFieldLength from panicking
+// once a sizeof/lenof tag has already supplied the length.
+type dynLenBox struct {
+	IImmutableBox
+	dynLen map[string]uint
+}
+
+func (b *dynLenBox) GetFieldLength(name string) uint {
+	if l, ok := b.dynLen[name]; ok {
+		return l
+	}
+	return b.IImmutableBox.GetFieldLength(name)
+}
+
+// countField sets field, an integer field, to n, matching Go's signed vs.
+// unsigned conversion rules for the field's own kind.
+func countField(field reflect.Value, n uint64) {
+	switch field.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
+		field.SetInt(int64(n))
+	default:
+		field.SetUint(n)
+	}
+}
+
+// fieldUint reads back an integer field's value as a uint64, regardless of
+// whether its Go kind is signed or unsigned.
+func fieldUint(field reflect.Value) uint64 {
+	switch field.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
+		return uint64(field.Int())
+	default:
+		return field.Uint()
+	}
+}
+
+// sliceElemCount returns the element count of target, a slice or array
+// field, for a `sizeof` tag.
+func sliceElemCount(target reflect.Value) uint64 {
+	return uint64(target.Len())
+}
+
+// sliceByteLength returns the on-the-wire byte length of target, a slice or
+// array field with a fixed per-element width of elemBits, for a `lenof`
+// tag. The wire width (elemBits), not Go's in-memory element size, is what
+// determines the byte count, since a field's `size=` tag can narrow an
+// element below its Go type's native width (e.g. `size=24` over a uint32).
+func sliceByteLength(target reflect.Value, elemBits uint) uint64 {
+	return uint64(target.Len()) * uint64(elemBits) / 8
+}
+
+// fieldSizeBits returns the bit width the named sibling field of v declares
+// via its own `size=` tag, or 0 if it has none (or has `size=dynamic`).
+// `lenof` needs this to convert its target's byte length to/from the
+// element count that `len=dynamic` deals in, so the target's width has to
+// be known statically.
+func fieldSizeBits(v reflect.Value, name string) uint {
+	sf, ok := v.Type().FieldByName(name)
+	if !ok {
+		return 0
+	}
+	s, ok := parseFieldTag(sf.Tag.Get("mp4"))["size"]
+	if !ok || s == "dynamic" {
+		return 0
+	}
+	n, err := strconv.ParseUint(s, 10, 8)
+	if err != nil {
+		return 0
+	}
+	return uint(n)
+}
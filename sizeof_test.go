@@ -0,0 +1,151 @@
+package mp4
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countBeforeBox exercises the common case: the count field precedes the
+// slice it describes, both inline and (EntryCount2/Entries2) inside a
+// FullBox-style extended sub-struct.
+type countBeforeBox struct {
+	mockBox
+	FullBox `mp4:"extend"`
+
+	EntryCount uint32   `mp4:"size=32,sizeof=Entries"`
+	Entries    []uint32 `mp4:"size=32,len=dynamic"`
+
+	Ext countBeforeExt `mp4:"extend"`
+}
+
+type countBeforeExt struct {
+	EntryCount2 uint16   `mp4:"size=16,sizeof=Entries2"`
+	Entries2    []uint16 `mp4:"size=16,len=dynamic"`
+}
+
+// countAfterBox exercises a count field placed after the slice it
+// describes, with the slice itself using `len=dynamic`. Marshal supports
+// this order unconditionally, since it never needs EntryCount's value --
+// Entries' own length already is the ground truth. Unmarshal can't: the
+// slice has to be sized before it's read off the wire, which means
+// EntryCount's value has to be known before Entries is reached, and here
+// it isn't (it comes later on the wire).
+type countAfterBox struct {
+	mockBox
+	FullBox `mp4:"extend"`
+
+	Entries    []uint32 `mp4:"size=32,len=dynamic"`
+	EntryCount uint32   `mp4:"size=32,sizeof=Entries"`
+}
+
+func TestSizeofMarshalCountBeforeSlice(t *testing.T) {
+	boxType := StrToBoxType("sz01")
+	mb := mockBox{Type: boxType}
+	AddBoxDef(&countBeforeBox{mockBox: mb}, 0)
+
+	src := countBeforeBox{
+		mockBox: mb,
+		Entries: []uint32{1, 2, 3},
+		Ext:     countBeforeExt{Entries2: []uint16{4, 5}},
+	}
+
+	buf := &bytes.Buffer{}
+	_, err := Marshal(buf, &src)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(3), src.EntryCount)
+	assert.Equal(t, uint16(2), src.Ext.EntryCount2)
+
+	dst := countBeforeBox{mockBox: mb}
+	_, err = Unmarshal(bytes.NewReader(buf.Bytes()), uint64(buf.Len())+boxHeaderSize, &dst)
+	require.NoError(t, err)
+	assert.Equal(t, src, dst)
+}
+
+func TestSizeofMarshalCountAfterSlice(t *testing.T) {
+	boxType := StrToBoxType("sz02")
+	mb := mockBox{Type: boxType}
+	AddBoxDef(&countAfterBox{mockBox: mb}, 0)
+
+	src := countAfterBox{
+		mockBox: mb,
+		Entries: []uint32{10, 20, 30, 40, 50},
+	}
+
+	buf := &bytes.Buffer{}
+	_, err := Marshal(buf, &src)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(5), src.EntryCount)
+
+	// Unmarshal genuinely can't resolve EntryCount's ordering: it hasn't
+	// been read yet when Entries needs sizing, so this falls through to
+	// the box's own GetFieldLength, same as any other len=dynamic field
+	// the box doesn't know how to resolve.
+	dst := countAfterBox{mockBox: mb}
+	assert.Panics(t, func() {
+		_, _ = Unmarshal(bytes.NewReader(buf.Bytes()), uint64(buf.Len())+boxHeaderSize, &dst)
+	})
+}
+
+type lenofBox struct {
+	mockBox
+	FullBox `mp4:"extend"`
+
+	PayloadLength uint32 `mp4:"size=32,lenof=Payload"`
+	Payload       []byte `mp4:"size=8,len=dynamic"`
+}
+
+// lenofNonByteBox exercises a `lenof` target whose element width (in both
+// bits-per-element and Go's native type size) differs from 1 byte, so a
+// byte length and an element count are not interchangeable.
+type lenofNonByteBox struct {
+	mockBox
+	FullBox `mp4:"extend"`
+
+	PayloadLength uint32   `mp4:"size=32,lenof=Entries"`
+	Entries       []uint32 `mp4:"size=24,len=dynamic"`
+}
+
+func TestLenofMarshalUnmarshalNonByteElements(t *testing.T) {
+	boxType := StrToBoxType("sz04")
+	mb := mockBox{Type: boxType}
+	AddBoxDef(&lenofNonByteBox{mockBox: mb}, 0)
+
+	src := lenofNonByteBox{
+		mockBox: mb,
+		Entries: []uint32{1, 2, 3},
+	}
+
+	buf := &bytes.Buffer{}
+	_, err := Marshal(buf, &src)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(9), src.PayloadLength) // 3 entries * 24 bits = 9 bytes
+
+	dst := lenofNonByteBox{mockBox: mb}
+	_, err = Unmarshal(bytes.NewReader(buf.Bytes()), uint64(buf.Len())+boxHeaderSize, &dst)
+	require.NoError(t, err)
+	assert.Equal(t, src, dst)
+}
+
+func TestLenofMarshalUnmarshal(t *testing.T) {
+	boxType := StrToBoxType("sz03")
+	mb := mockBox{Type: boxType}
+	AddBoxDef(&lenofBox{mockBox: mb}, 0)
+
+	src := lenofBox{
+		mockBox: mb,
+		Payload: []byte("abema"),
+	}
+
+	buf := &bytes.Buffer{}
+	_, err := Marshal(buf, &src)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(5), src.PayloadLength)
+
+	dst := lenofBox{mockBox: mb}
+	_, err = Unmarshal(bytes.NewReader(buf.Bytes()), uint64(buf.Len())+boxHeaderSize, &dst)
+	require.NoError(t, err)
+	assert.Equal(t, src, dst)
+}
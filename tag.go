@@ -0,0 +1,23 @@
+package mp4
+
+import "strings"
+
+// fieldTag is the parsed form of a struct field's `mp4:"..."` tag: a set of
+// comma-separated keys, each optionally carrying a "=value" payload.
+type fieldTag map[string]string
+
+// parseFieldTag splits a raw `mp4` tag string into its component keys.
+func parseFieldTag(raw string) fieldTag {
+	tag := fieldTag{}
+	for _, elem := range strings.Split(raw, ",") {
+		if elem == "" {
+			continue
+		}
+		if i := strings.IndexByte(elem, '='); i >= 0 {
+			tag[elem[:i]] = elem[i+1:]
+		} else {
+			tag[elem] = ""
+		}
+	}
+	return tag
+}
@@ -0,0 +1,306 @@
+package mp4
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+
+	"github.com/abema/go-mp4/bitio"
+)
+
+// Unmarshal populates box's fields from the payload read from r, which must
+// be positioned at the start of the payload (just after the box header).
+// size is the box's total size, header included. It returns the number of
+// payload bytes read.
+func Unmarshal(r io.ReadSeeker, size uint64, box IImmutableBox) (uint64, error) {
+	u := &unmarshaller{
+		reader: bitio.NewReadSeeker(r),
+		size:   size,
+		dynLen: make(map[string]uint),
+	}
+	cfo := IImmutableBox(&dynLenBox{IImmutableBox: box, dynLen: u.dynLen})
+	if err := u.unmarshalStruct(cfo, reflect.ValueOf(box).Elem(), false); err != nil {
+		return 0, err
+	}
+	if !checkSupportedVersion(box.GetType(), box.GetVersion()) {
+		return 0, fmt.Errorf("box %s: unsupported version %d", box.GetType(), box.GetVersion())
+	}
+	u.rbits += u.reader.Align()
+	return u.rbits / 8, nil
+}
+
+type unmarshaller struct {
+	reader bitio.ReadSeeker
+	size   uint64
+	rbits  uint64
+
+	// dynLen caches the element count that each sizeof/lenof field gives
+	// the field it describes, keyed by that field's name, so that field's
+	// own len=dynamic resolves without the box implementing GetFieldLength.
+	dynLen map[string]uint
+}
+
+func (u *unmarshaller) leftBits() uint64 {
+	if u.size < boxHeaderSize {
+		return 0
+	}
+	payloadBits := (u.size - boxHeaderSize) * 8
+	if u.rbits >= payloadBits {
+		return 0
+	}
+	return payloadBits - u.rbits
+}
+
+func (u *unmarshaller) unmarshalStruct(cfo IImmutableBox, v reflect.Value, le bool) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tagStr, ok := sf.Tag.Lookup("mp4")
+		if !ok {
+			continue
+		}
+		cfg, err := readFieldConfig(cfo, v, sf.Name, parseFieldTag(tagStr))
+		if err != nil {
+			return err
+		}
+		if !fieldEnabled(cfg) {
+			continue
+		}
+		if err := u.unmarshalField(cfo, v.Field(i), cfg, cfg.endian.resolve(le)); err != nil {
+			return fmt.Errorf("failed to unmarshal field %s: %w", sf.Name, err)
+		}
+		if cfg.sizeofTarget != "" {
+			u.dynLen[cfg.sizeofTarget] = uint(fieldUint(v.Field(i)))
+		}
+		if cfg.lenofTarget != "" {
+			elemBits := fieldSizeBits(v, cfg.lenofTarget)
+			if elemBits == 0 {
+				return fmt.Errorf("lenof target %s of field %s needs a static size tag", cfg.lenofTarget, sf.Name)
+			}
+			// The field just read carries a byte length; len=dynamic wants
+			// the target's element count, so convert using its wire width.
+			u.dynLen[cfg.lenofTarget] = uint(fieldUint(v.Field(i)) * 8 / uint64(elemBits))
+		}
+	}
+	return nil
+}
+
+func (u *unmarshaller) unmarshalField(cfo IImmutableBox, v reflect.Value, cfg FieldConfig, le bool) error {
+	if n, override, err := cfo.OnReadField(cfg.name, u.reader, u.leftBits()); err != nil {
+		return err
+	} else if override {
+		u.rbits += n
+		return nil
+	}
+
+	if fp, ok := asFieldPacker(v); ok {
+		n, err := fp.MP4Unpack(u.reader, cfg, u.leftBits())
+		if err != nil {
+			return err
+		}
+		u.rbits += n
+		return nil
+	}
+
+	if cfg.extend {
+		ev := v
+		if ev.Kind() == reflect.Ptr {
+			if ev.IsNil() {
+				ev.Set(reflect.New(ev.Type().Elem()))
+			}
+			ev = ev.Elem()
+		}
+		return u.unmarshalStruct(cfo, ev, le)
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return u.unmarshalField(cfo, v.Elem(), cfg, le)
+	case reflect.Struct:
+		return u.unmarshalStruct(cfo, v, le)
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := u.unmarshalValue(v.Index(i), cfg, le); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Slice:
+		l := v.Len()
+		if cfg.lengthDynamic {
+			l = int(cfg.cfo.GetFieldLength(cfg.name))
+		} else if cfg.length != lengthUnlimited {
+			l = int(cfg.length)
+		}
+		sl := reflect.MakeSlice(v.Type(), l, l)
+		for i := 0; i < l; i++ {
+			if err := u.unmarshalValue(sl.Index(i), cfg, le); err != nil {
+				return err
+			}
+		}
+		v.Set(sl)
+		return nil
+	case reflect.String:
+		s, err := u.unmarshalString(cfg)
+		if err != nil {
+			return err
+		}
+		v.SetString(s)
+		return nil
+	default:
+		return u.unmarshalValue(v, cfg, le)
+	}
+}
+
+func (u *unmarshaller) unmarshalString(cfg FieldConfig) (string, error) {
+	var bs []byte
+	for {
+		b, err := u.reader.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		u.rbits += 8
+		if b == 0 {
+			break
+		}
+		bs = append(bs, b)
+	}
+	return string(bs), nil
+}
+
+func (u *unmarshaller) unmarshalValue(v reflect.Value, cfg FieldConfig, le bool) error {
+	if cfg.cnst != "" {
+		expect, err := strconv.ParseUint(cfg.cnst, 0, 64)
+		if err != nil {
+			return fmt.Errorf("invalid const tag: %s", cfg.cnst)
+		}
+		n, err := u.readInt(cfg.size, le)
+		if err != nil {
+			return err
+		}
+		if n != expect {
+			return fmt.Errorf("unexpected value for const field: expected=%d actual=%d", expect, n)
+		}
+		v.SetUint(n)
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		n, err := u.readBits(uint64(cfg.size))
+		if err != nil {
+			return err
+		}
+		v.SetBool(n != 0)
+		return nil
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
+		n, err := u.unmarshalUint(cfg, le)
+		if err != nil {
+			return err
+		}
+		v.SetInt(signExtend(n, cfg.size))
+		return nil
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
+		n, err := u.unmarshalUint(cfg, le)
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		f, err := u.unmarshalFloat(cfg, le)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+		return nil
+	default:
+		return fmt.Errorf("unsupported field kind: %s", v.Kind())
+	}
+}
+
+// unmarshalFloat reads a `float16` or `fixed=I.F` tagged field and converts
+// its wire bit pattern to a float64.
+func (u *unmarshaller) unmarshalFloat(cfg FieldConfig, le bool) (float64, error) {
+	switch {
+	case cfg.float16:
+		n, err := u.readInt(cfg.size, le)
+		if err != nil {
+			return 0, err
+		}
+		return float64(decodeFloat16(uint16(n))), nil
+	case cfg.fixed:
+		n, err := u.readInt(cfg.size, le)
+		if err != nil {
+			return 0, err
+		}
+		return decodeFixed(n, cfg.size, cfg.fixedFrac), nil
+	default:
+		return 0, fmt.Errorf("float field %s requires a float16 or fixed tag", cfg.name)
+	}
+}
+
+func (u *unmarshaller) unmarshalUint(cfg FieldConfig, le bool) (uint64, error) {
+	if cfg.varint {
+		return u.readUvarint()
+	}
+	return u.readInt(cfg.size, le)
+}
+
+func (u *unmarshaller) readBits(size uint64) (uint64, error) {
+	n, err := u.reader.ReadBits(size)
+	if err != nil {
+		return 0, err
+	}
+	u.rbits += size
+	return n, nil
+}
+
+// readInt reads an integer field of size bits, byte-swapping it when le is
+// set and the field is byte-aligned. Sub-byte little-endian fields don't
+// occur in practice, so size%8 != 0 always reads big-endian.
+func (u *unmarshaller) readInt(size uint, le bool) (uint64, error) {
+	if !le || size%8 != 0 || size == 0 {
+		return u.readBits(uint64(size))
+	}
+	var v uint64
+	for i := uint(0); i < size; i += 8 {
+		b, err := u.reader.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		u.rbits += 8
+		v |= uint64(b) << i
+	}
+	return v, nil
+}
+
+func (u *unmarshaller) readUvarint() (uint64, error) {
+	var v uint64
+	for {
+		b, err := u.reader.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		u.rbits += 8
+		v = (v << 7) | uint64(b&0x7f)
+		if b&0x80 == 0 {
+			return v, nil
+		}
+	}
+}
+
+func signExtend(n uint64, size uint) int64 {
+	if size == 0 || size >= 64 {
+		return int64(n)
+	}
+	signBit := uint64(1) << (size - 1)
+	if n&signBit != 0 {
+		n -= uint64(1) << size
+	}
+	return int64(n)
+}